@@ -6,23 +6,31 @@ import (
 
 	"github.com/RTradeLtd/Temporal/models"
 	jwt "github.com/appleboy/gin-jwt"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
 )
 
 var realmName = "temporal-realm"
 
-// JwtConfigGenerate is used to generate our JWT configuration
-func JwtConfigGenerate(jwtKey string, db *gorm.DB) *jwt.GinJWTMiddleware {
+// JwtConfigGenerate is used to generate our JWT configuration. authCfg controls whether
+// plaintext-password auth, wallet-signature auth, or both are accepted on login.
+func JwtConfigGenerate(jwtKey string, db *gorm.DB, authCfg AuthConfig) *jwt.GinJWTMiddleware {
 
-	// will implement metamaks/msg signing with ethereum accounts
-	// as the authentication metho
 	authMiddleware := &jwt.GinJWTMiddleware{
 		Realm:      realmName,
 		Key:        []byte(jwtKey),
 		Timeout:    time.Hour * 24,
 		MaxRefresh: time.Hour * 24,
 		Authenticator: func(userId string, password string, c *gin.Context) (string, bool) { // userId = uploader address
+			// wallet-signature auth: userId is the wallet address, and password carries
+			// "<nonce>:<signature>" from the challenge handed out by /auth/nonce
+			if authCfg.WalletAuthEnabled && common.IsHexAddress(userId) {
+				return authenticateWallet(db, userId, password)
+			}
+			if !authCfg.PasswordAuthEnabled {
+				return userId, false
+			}
 			userManager := models.NewUserManager(db)
 			validAuth, err := userManager.ComparePlaintextPasswordToHash(userId, password)
 			if err != nil {
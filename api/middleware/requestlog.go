@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"time"
+
+	jwt "github.com/appleboy/gin-jwt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// requestIDHeader is the header Temporal reads an inbound request id from, and echoes
+// back on the response so a client and server agree on the same trace id
+const requestIDHeader = "X-Request-Id"
+
+// RequestLogger generates a request id (or reuses the caller-supplied one), stashes it
+// and a child of base pre-tagged with request metadata in the gin context under
+// "request-id"/"logger", and emits an access-log line once the handler chain completes.
+// Handlers should pull the logger back out via c.Get("logger") rather than reconstructing
+// it, so every log line for a request shares the same trace id. Register TagAuthenticatedUser
+// after the JWT middleware (and before route handlers) to also have "user" tagged on for
+// every log line a handler emits, not just the final access-log line below.
+func RequestLogger(base *zap.SugaredLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+		c.Header(requestIDHeader, requestID)
+		c.Set("request-id", requestID)
+
+		logger := base.With(
+			"request-id", requestID,
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"remote-ip", c.ClientIP(),
+		)
+		c.Set("logger", logger)
+
+		start := time.Now()
+		c.Next()
+
+		// TagAuthenticatedUser, if it ran later in the chain, already re-tagged "logger"
+		// with the user claim - pull it back out so the access-log line carries it too
+		logger = LoggerFromContext(c, logger)
+		logger.Infow("request completed",
+			"status", c.Writer.Status(),
+			"latency", time.Since(start).String(),
+			"bytes", c.Writer.Size(),
+		)
+	}
+}
+
+// LoggerFromContext returns the *zap.SugaredLogger stashed in c under "logger", falling
+// back to fallback if none has been set yet.
+func LoggerFromContext(c *gin.Context, fallback *zap.SugaredLogger) *zap.SugaredLogger {
+	if logger, exists := c.Get("logger"); exists {
+		if l, ok := logger.(*zap.SugaredLogger); ok {
+			return l
+		}
+	}
+	return fallback
+}
+
+// TagAuthenticatedUser re-tags the context logger stashed by RequestLogger with the
+// authenticated user's id, once the JWT middleware has populated claims. It must be
+// registered after the JWT middleware and before any route handlers, since RequestLogger
+// itself runs before JWT and so cannot see the claims from within its own c.Next() call.
+// Handlers that log via the context logger (see api/v2/log.go's loggerFromContext) only
+// pick up "user" if this middleware re-c.Set's it before they run.
+func TagAuthenticatedUser(c *gin.Context) {
+	claims := jwt.ExtractClaims(c)
+	if claims == nil {
+		c.Next()
+		return
+	}
+	userID, ok := claims["id"]
+	if !ok {
+		c.Next()
+		return
+	}
+	logger := LoggerFromContext(c, nil)
+	if logger == nil {
+		c.Next()
+		return
+	}
+	c.Set("logger", logger.With("user", userID))
+	c.Next()
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/RTradeLtd/Temporal/models"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/jinzhu/gorm"
+)
+
+// AuthConfig toggles which credential types JwtConfigGenerate's Authenticator accepts.
+// Both may be enabled at once, in which case a login request is routed to wallet auth
+// whenever the supplied user id parses as an Ethereum address.
+type AuthConfig struct {
+	PasswordAuthEnabled bool
+	WalletAuthEnabled   bool
+}
+
+// walletCredentialSep separates the nonce from the signature in the "password" field of
+// a wallet-signature login request, i.e. "<nonce>:<signature>"
+const walletCredentialSep = ":"
+
+// GenerateNonce issues a single-use login nonce for address, persisting it with a short
+// TTL so authenticateWallet can later consume it. It backs the /auth/nonce handler.
+func GenerateNonce(db *gorm.DB, address string) (string, error) {
+	return models.NewNonceManager(db).NewNonce(strings.ToLower(address))
+}
+
+// authenticateWallet recovers the public key that produced signature over the
+// Ethereum-signed-message hash of the nonce previously issued to address, and checks that
+// it derives back to address. The nonce is only consumed once the signature has checked
+// out, so an unauthenticated caller who merely knows address (public) can't grief-lock a
+// legitimate login in progress by submitting a junk signature to burn the nonce first. On
+// success the lowercased address is returned as the JWT's user id.
+func authenticateWallet(db *gorm.DB, address, credentials string) (string, bool) {
+	address = strings.ToLower(address)
+	parts := strings.SplitN(credentials, walletCredentialSep, 2)
+	if len(parts) != 2 {
+		return address, false
+	}
+	nonce, signature := parts[0], parts[1]
+
+	nonceManager := models.NewNonceManager(db)
+	valid, err := nonceManager.VerifyNonce(address, nonce)
+	if err != nil || !valid {
+		return address, false
+	}
+
+	sig, err := hexutil.Decode(signature)
+	if err != nil || len(sig) != 65 {
+		return address, false
+	}
+	// crypto.SigToPub expects the recovery id in the last byte to be 0 or 1, but wallets
+	// (MetaMask et al) produce signatures with it as 27/28
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+
+	pubKey, err := crypto.SigToPub(signHash([]byte(nonce)), sig)
+	if err != nil {
+		return address, false
+	}
+	recovered := strings.ToLower(crypto.PubkeyToAddress(*pubKey).Hex())
+	if recovered != address {
+		return address, false
+	}
+
+	if err := nonceManager.ConsumeNonce(address, nonce); err != nil {
+		return address, false
+	}
+	return address, true
+}
+
+// signHash reproduces the prefix go-ethereum wallets apply before signing an arbitrary
+// message, so the recovered address matches what the user approved in their wallet
+func signHash(data []byte) []byte {
+	msg := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(data), data)
+	return crypto.Keccak256([]byte(msg))
+}
@@ -0,0 +1,36 @@
+package v2
+
+import (
+	"net/http"
+
+	"github.com/RTradeLtd/Temporal/api/middleware"
+	"github.com/RTradeLtd/Temporal/eh"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// nonceRequest is the body expected by getAuthNonce
+type nonceRequest struct {
+	Address string `json:"address" binding:"required"`
+}
+
+// getAuthNonce hands out a single-use login nonce for the given address. The client signs
+// it with their wallet and posts it back through the normal JWT login route as
+// "<nonce>:<signature>" to complete a wallet-signature login.
+func (api *API) getAuthNonce(c *gin.Context) {
+	var req nonceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.LogError(c, err, eh.InvalidRequestError)(http.StatusBadRequest)
+		return
+	}
+	if !common.IsHexAddress(req.Address) {
+		FailWithMessage(c, eh.InvalidAddressError)
+		return
+	}
+	nonce, err := middleware.GenerateNonce(api.dbm.DB, req.Address)
+	if err != nil {
+		api.LogError(c, err, eh.NonceGenerationError)(http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"nonce": nonce})
+}
@@ -2,21 +2,29 @@ package v2
 
 import (
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
+// loggerFromContext returns the per-request logger middleware.RequestLogger stashed under
+// "logger", already tagged with request id/method/path/remote-ip (and user, once
+// middleware.TagAuthenticatedUser has run). Falls back to
+// api.l tagged with just the request id if the middleware didn't run for this request.
+func (api *API) loggerFromContext(c *gin.Context) *zap.SugaredLogger {
+	if logger, exists := c.Get("logger"); exists {
+		if l, ok := logger.(*zap.SugaredLogger); ok {
+			return l
+		}
+	}
+	return api.l.With("request-id", c.GetHeader("X-Request-Id"))
+}
+
 // LogError is a wrapper used by the API to handle logging of errors. Returns a
 // callback to also fail a gin context with an optional status code, which
 // defaults to http.StatusInternalServerError. Fields is an optional set of
 // params provided in pairs, where the first of a pair is the key, and the second
 // is the value
-//
-// Passing in the initial gin.Context to LogError is used to extract the X-Request-ID associated
-// with the given request to make it easier to debug user-submitted erros.
 func (api *API) LogError(c *gin.Context, err error, message string, fields ...interface{}) func(code ...int) {
-	// create base entry with the associated request id
-	// for some reason this is being set as `X-Request-Id` despite initially
-	// setting the header to `X-Request-ID`
-	var logger = api.l.With("request-id", c.GetHeader("X-Request-Id"))
+	logger := api.loggerFromContext(c)
 
 	// write log
 	if fields != nil && len(fields)%2 == 0 {
@@ -31,3 +39,15 @@ func (api *API) LogError(c *gin.Context, err error, message string, fields ...in
 	}
 	return func(code ...int) { FailWithMessage(c, message, code...) }
 }
+
+// LogInfo logs an info-level message tagged with the request-scoped fields
+// middleware.RequestLogger attached to c
+func (api *API) LogInfo(c *gin.Context, message string, fields ...interface{}) {
+	api.loggerFromContext(c).Infow(message, fields...)
+}
+
+// LogDebug logs a debug-level message tagged with the request-scoped fields
+// middleware.RequestLogger attached to c
+func (api *API) LogDebug(c *gin.Context, message string, fields ...interface{}) {
+	api.loggerFromContext(c).Debugw(message, fields...)
+}
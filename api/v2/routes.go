@@ -0,0 +1,10 @@
+package v2
+
+import "github.com/gin-gonic/gin"
+
+// RegisterAuthRoutes wires the wallet-signature auth endpoints onto router, so a client can
+// actually reach getAuthNonce to obtain the challenge authenticateWallet expects back as
+// part of "<nonce>:<signature>" on login.
+func (api *API) RegisterAuthRoutes(router gin.IRouter) {
+	router.POST("/auth/nonce", api.getAuthNonce)
+}
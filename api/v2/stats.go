@@ -18,8 +18,16 @@ func (api *API) getStats(c *gin.Context) {
 		FailNotAuthorized(c, eh.UnAuthorizedAdminAccess)
 		return
 	}
+	// surface queue backlog/throughput from the RabbitMQ management API alongside the
+	// existing HTTP stats, so operators can see both from one admin call
+	queueStats, err := api.queues.Stats()
+	if err != nil {
+		api.LogError(c, err, eh.QueueStatsError)(http.StatusInternalServerError)
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"version":  api.version,
 		"response": stats.Report(),
+		"queues":   queueStats,
 	})
 }
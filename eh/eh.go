@@ -0,0 +1,25 @@
+// Package eh holds the user-facing error message strings the v2 API logs and returns to
+// callers, so the same wording is used everywhere a given failure is reported instead of
+// each handler inventing its own.
+package eh
+
+// InvalidRequestError is returned when a request body fails to bind/validate
+const InvalidRequestError = "invalid request body"
+
+// InvalidAddressError is returned when a caller-supplied value isn't a valid hex-encoded
+// wallet address
+const InvalidAddressError = "invalid eth address"
+
+// NonceGenerationError is returned when generating a wallet-auth login nonce fails
+const NonceGenerationError = "failed to generate nonce"
+
+// NoAPITokenError is returned when a request has no authenticated user attached to it
+const NoAPITokenError = "no api token provided"
+
+// UnAuthorizedAdminAccess is returned when an authenticated, non-admin user calls an
+// admin-only route
+const UnAuthorizedAdminAccess = "unauthorized access to admin route"
+
+// QueueStatsError is returned when querying the RabbitMQ management API for queue/exchange
+// stats fails
+const QueueStatsError = "failed to retrieve queue stats"
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// nonceTTL bounds how long a nonce issued by NewNonce is accepted by VerifyNonce, so a challenge handed out by /auth/nonce can't be replayed long after issuance
+const nonceTTL = 5 * time.Minute
+
+// Nonce is a single-use login challenge issued to a wallet address by NewNonce and
+// redeemed by ConsumeNonce as part of wallet-signature authentication.
+type Nonce struct {
+	gorm.Model
+	Address   string `gorm:"type:varchar(255);index"`
+	Nonce     string `gorm:"type:varchar(255)"`
+	ExpiresAt time.Time
+}
+
+// NonceManager is used to interact with nonce records in the database
+type NonceManager struct {
+	DB *gorm.DB
+}
+
+// NewNonceManager instantiates a NonceManager for interacting with nonce records
+func NewNonceManager(db *gorm.DB) *NonceManager {
+	return &NonceManager{DB: db}
+}
+
+// NewNonce issues a fresh, random nonce for address, replacing any nonce previously issued
+// to it, and returns the nonce to be handed back to the caller as a signing challenge.
+func (nm *NonceManager) NewNonce(address string) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	nonce := hex.EncodeToString(raw)
+
+	if err := nm.DB.Where("address = ?", address).Delete(&Nonce{}).Error; err != nil {
+		return "", err
+	}
+	record := Nonce{Address: address, Nonce: nonce, ExpiresAt: time.Now().Add(nonceTTL)}
+	if err := nm.DB.Create(&record).Error; err != nil {
+		return "", err
+	}
+	return nonce, nil
+}
+
+// VerifyNonce reports whether nonce is the current, unexpired nonce on file for address,
+// without consuming it. Callers must verify the signature over the nonce before calling
+// ConsumeNonce, so an invalid signature never burns the address's outstanding nonce.
+func (nm *NonceManager) VerifyNonce(address, nonce string) (bool, error) {
+	var record Nonce
+	err := nm.DB.Where("address = ? AND nonce = ?", address, nonce).First(&record).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return time.Now().Before(record.ExpiresAt), nil
+}
+
+// ConsumeNonce deletes the nonce on file for address so it cannot be redeemed again. It
+// should only be called after VerifyNonce and the signature over the nonce have both
+// succeeded.
+func (nm *NonceManager) ConsumeNonce(address, nonce string) error {
+	return nm.DB.Where("address = ? AND nonce = ?", address, nonce).Delete(&Nonce{}).Error
+}
@@ -0,0 +1,118 @@
+package queue
+
+import (
+	"context"
+	"errors"
+
+	"github.com/streadway/amqp"
+)
+
+// dlxSuffix/retrySuffix/failedSuffix name the dead-letter exchange and companion queues
+// declareDeadLetter sets up alongside every work queue
+const (
+	dlxSuffix    = ".dlx"
+	retrySuffix  = ".retry"
+	failedSuffix = ".failed"
+
+	// retryDelayMS is how long a Nack'd message waits in the retry queue before RabbitMQ
+	// dead-letters it back onto the original queue for redelivery
+	retryDelayMS = 30000
+	// maxMessageRetries bounds how many times a message may cycle through the retry queue
+	// before NackForRetry parks it in the failed queue instead
+	maxMessageRetries = 5
+)
+
+// declareDeadLetter declares the dead-letter exchange backing qm.QueueName along with its
+// two companion queues: <queue>.retry, which holds a Nack'd message for retryDelayMS before
+// RabbitMQ dead-letters it back onto qm.QueueName for redelivery, and <queue>.failed, where
+// NackForRetry parks a message once it has exceeded maxMessageRetries.
+func (qm *Manager) declareDeadLetter() error {
+	dlxName := qm.QueueName + dlxSuffix
+	retryName := qm.QueueName + retrySuffix
+	failedName := qm.QueueName + failedSuffix
+
+	if err := qm.channelRef().ExchangeDeclare(dlxName, "direct", true, false, false, false, nil); err != nil {
+		return err
+	}
+	if _, err := qm.channelRef().QueueDeclare(retryName, true, false, false, false, amqp.Table{
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": qm.QueueName,
+		"x-message-ttl":             int32(retryDelayMS),
+	}); err != nil {
+		return err
+	}
+	// DeclareQueue sets x-dead-letter-routing-key to qm.QueueName on the work queue, so a
+	// Nack'd message arrives here with that key whichever exchange it was published through
+	if err := qm.channelRef().QueueBind(retryName, qm.QueueName, dlxName, false, nil); err != nil {
+		return err
+	}
+	_, err := qm.channelRef().QueueDeclare(failedName, true, false, false, false, nil)
+	return err
+}
+
+// DeathCount reads the x-death header RabbitMQ stamps on a message each time it passes
+// through a dead-letter exchange, returning how many times that has happened so far.
+func DeathCount(d amqp.Delivery) int64 {
+	raw, ok := d.Headers["x-death"]
+	if !ok {
+		return 0
+	}
+	deaths, ok := raw.([]interface{})
+	if !ok || len(deaths) == 0 {
+		return 0
+	}
+	death, ok := deaths[0].(amqp.Table)
+	if !ok {
+		return 0
+	}
+	count, _ := death["count"].(int64)
+	return count
+}
+
+// NackForRetry should be called by a queue's Process* handler in place of d.Nack when a
+// message fails processing. Once a message has already cycled through the retry queue
+// maxMessageRetries times, it is republished straight to <queue>.failed and acked instead
+// of being nacked again, so a permanently-bad message doesn't loop forever.
+func (qm *Manager) NackForRetry(d amqp.Delivery) error {
+	if DeathCount(d) < maxMessageRetries {
+		return d.Nack(false, false)
+	}
+	if err := qm.channelRef().Publish("", qm.QueueName+failedSuffix, false, false, amqp.Publishing{
+		DeliveryMode: amqp.Persistent,
+		ContentType:  d.ContentType,
+		Headers:      d.Headers,
+		Body:         d.Body,
+	}); err != nil {
+		return err
+	}
+	return d.Ack(false)
+}
+
+// ProcessWithRetry drains msgs until ctx is done, handing each delivery to handler. A
+// handler error is treated as a transient failure and the delivery is routed through
+// NackForRetry, so it gets a bounded number of trips through the retry queue before
+// landing in <queue>.failed instead of being dropped or retried forever; a nil error Acks
+// the delivery. Process* consumers should use this instead of Ack/Nack'ing deliveries
+// directly, so every queue gets the same retry/dead-letter behavior.
+func (qm *Manager) ProcessWithRetry(ctx context.Context, msgs <-chan amqp.Delivery, handler func(amqp.Delivery) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case d, ok := <-msgs:
+			if !ok {
+				return errors.New("delivery channel closed")
+			}
+			if err := handler(d); err != nil {
+				qm.LogError(err, "failed to process delivery, nacking for retry")
+				if nackErr := qm.NackForRetry(d); nackErr != nil {
+					qm.LogError(nackErr, "failed to nack delivery for retry")
+				}
+				continue
+			}
+			if err := d.Ack(false); err != nil {
+				qm.LogError(err, "failed to ack delivery")
+			}
+		}
+	}
+}
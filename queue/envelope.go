@@ -0,0 +1,75 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/streadway/amqp"
+)
+
+// protoContentType marks a published message body as protobuf-encoded, so DecodeEnvelope
+// can tell it apart from the json/text-plain messages that may still be in flight during a
+// rolling upgrade
+const protoContentType = "application/x-protobuf"
+
+// schemaNameHeader/schemaVersionHeader identify the generated message type and schema
+// revision a published message was encoded with, so a consumer can dispatch on them and
+// reject (nack + DLX, see NackForRetry) a message whose schema it doesn't understand
+const (
+	schemaNameHeader    = "schema-name"
+	schemaVersionHeader = "schema-version"
+)
+
+// SchemaMessage is implemented by every generated queue/proto message. PublishMessage and
+// PublishMessageWithExchange require it so every payload going over the wire carries a
+// schema name/version alongside its protobuf-encoded body.
+type SchemaMessage interface {
+	proto.Message
+	SchemaName() string
+	SchemaVersion() string
+}
+
+// envelopeHeaders builds the amqp.Table stamped on every published message identifying
+// the schema it was encoded with
+func envelopeHeaders(msg SchemaMessage) amqp.Table {
+	return amqp.Table{
+		schemaNameHeader:    msg.SchemaName(),
+		schemaVersionHeader: msg.SchemaVersion(),
+	}
+}
+
+// DecodeEnvelope decodes a delivery's body into dst, a pointer to the generated
+// queue/proto message the caller expects it to be. Deliveries published before the
+// protobuf migration landed (ContentType "text/plain") are decoded as JSON instead, so a
+// rolling upgrade doesn't drop in-flight messages. A delivery whose schema-version header
+// doesn't match dst's current schema version is rejected with an error, for the caller to
+// turn into a NackForRetry instead of attempting to interpret bytes it doesn't understand.
+func DecodeEnvelope(d amqp.Delivery, dst SchemaMessage) error {
+	if d.ContentType != protoContentType {
+		return json.Unmarshal(d.Body, dst)
+	}
+	if v, ok := d.Headers[schemaVersionHeader].(string); ok && v != dst.SchemaVersion() {
+		return fmt.Errorf("unsupported %s schema version %q, this binary expects %q", dst.SchemaName(), v, dst.SchemaVersion())
+	}
+	return proto.Unmarshal(d.Body, dst)
+}
+
+// ConsumeTyped drains msgs like ProcessWithRetry, but first decodes each delivery (via
+// DecodeEnvelope) into a fresh value of the same concrete type as sample before handing it
+// to handler. A delivery that fails to decode - including one whose schema-version header
+// doesn't match sample's - is treated the same as a handler error and routed through
+// NackForRetry instead of ever reaching handler, so Process* consumers get schema
+// rejection for free by switching to this instead of ProcessWithRetry.
+func (qm *Manager) ConsumeTyped(ctx context.Context, msgs <-chan amqp.Delivery, sample SchemaMessage, handler func(amqp.Delivery, SchemaMessage) error) error {
+	msgType := reflect.TypeOf(sample).Elem()
+	return qm.ProcessWithRetry(ctx, msgs, func(d amqp.Delivery) error {
+		dst := reflect.New(msgType).Interface().(SchemaMessage)
+		if err := DecodeEnvelope(d, dst); err != nil {
+			return err
+		}
+		return handler(d, dst)
+	})
+}
@@ -0,0 +1,34 @@
+package proto
+
+// schema version constants pin the current wire-schema revision for each message below.
+// Bump the relevant constant whenever a breaking change is made to that message's .proto
+// definition; queue.DecodeEnvelope (and so queue.ConsumeTyped, which nacks + DLXs) rejects
+// any delivery whose schema-version header doesn't match what this binary was built
+// against. queue.ConsumeMessages' Process* handlers do not decode through it and make no
+// such check.
+const (
+	IPFSPinRequestSchemaVersion  = "1"
+	IPFSKeyCreationSchemaVersion = "1"
+	EmailSendSchemaVersion       = "1"
+)
+
+// SchemaName returns the stable, version-independent name used as the schema-name header
+// on every message published for this type
+func (m *IPFSPinRequest) SchemaName() string { return "IPFSPinRequest" }
+
+// SchemaVersion returns the wire-schema revision this binary publishes/expects
+func (m *IPFSPinRequest) SchemaVersion() string { return IPFSPinRequestSchemaVersion }
+
+// SchemaName returns the stable, version-independent name used as the schema-name header
+// on every message published for this type
+func (m *IPFSKeyCreation) SchemaName() string { return "IPFSKeyCreation" }
+
+// SchemaVersion returns the wire-schema revision this binary publishes/expects
+func (m *IPFSKeyCreation) SchemaVersion() string { return IPFSKeyCreationSchemaVersion }
+
+// SchemaName returns the stable, version-independent name used as the schema-name header
+// on every message published for this type
+func (m *EmailSend) SchemaName() string { return "EmailSend" }
+
+// SchemaVersion returns the wire-schema revision this binary publishes/expects
+func (m *EmailSend) SchemaVersion() string { return EmailSendSchemaVersion }
@@ -8,6 +8,7 @@ import (
 	"os"
 	"sync"
 
+	"github.com/golang/protobuf/proto"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/RTradeLtd/config"
@@ -37,16 +38,25 @@ func (qm *Manager) parseQueueName(queueName string) error {
 	return nil
 }
 
-// Initialize is used to connect to the given queue, for publishing or consuming purposes
-func Initialize(queueName, connectionURL string, publish, service bool, logFilePath ...string) (*Manager, error) {
-	conn, err := setupConnection(connectionURL)
+// Initialize is used to connect to the given queue, for publishing or consuming purposes.
+// The connection is supervised for the lifetime of the returned Manager: if the broker
+// drops the TCP connection or closes the channel, a background goroutine redials with
+// exponential backoff and replays the declarations made below so callers never have to
+// re-run Initialize themselves. tlsCfg may be nil to use a plain TCP connection; it is
+// also honored automatically for amqps:// connection URLs even when nil. mgmtCfg may be
+// nil if the returned Manager will never have Stats called on it.
+func Initialize(queueName, connectionURL string, publish, service bool, tlsCfg *QueueTLSConfig, mgmtCfg *ManagementConfig, logFilePath ...string) (*Manager, error) {
+	conn, err := setupConnection(connectionURL, tlsCfg)
 	if err != nil {
 		return nil, err
 	}
-	qm := Manager{connection: conn}
+	qm := Manager{connection: conn, connectionURL: connectionURL, tlsConfig: tlsCfg, logicalQueue: queueName, publish: publish, management: mgmtCfg}
 	if err := qm.OpenChannel(); err != nil {
 		return nil, err
 	}
+	if err := qm.enableConfirms(); err != nil {
+		return nil, err
+	}
 
 	qm.QueueName = queueName
 	qm.Service = queueName
@@ -77,46 +87,58 @@ func Initialize(queueName, connectionURL string, publish, service bool, logFileP
 	}
 	// we only need to declare a queue if we're consuming (aka, service)
 	if publish {
+		// a publish-only Manager never calls ConsumeMessages, so it has to start its own
+		// supervisor here to get reconnect/flush-on-reconnect behavior at all
+		go qm.superviseConnection(context.Background(), nil, nil)
 		return &qm, nil
 	}
 	if err := qm.DeclareQueue(); err != nil {
 		return nil, err
 	}
+	// a consuming Manager's supervisor is started by ConsumeMessages instead, which owns
+	// resuming bindAndConsume after a reconnect; starting a second one here would race it
+	// for the same notify-close channels
 	return &qm, nil
 }
 
-func setupConnection(connectionURL string) (*amqp.Connection, error) {
-	conn, err := amqp.Dial(connectionURL)
-	if err != nil {
-		return nil, err
-	}
-	return conn, nil
-}
-
 // OpenChannel is used to open a channel to the rabbitmq server
 func (qm *Manager) OpenChannel() error {
-	ch, err := qm.connection.Channel()
+	qm.connMu.RLock()
+	conn := qm.connection
+	qm.connMu.RUnlock()
+
+	ch, err := conn.Channel()
 	if err != nil {
 		return err
 	}
 	if qm.logger != nil {
 		qm.LogInfo("channel opened")
 	}
+	qm.connMu.Lock()
 	qm.channel = ch
-	return qm.channel.Qos(10, 0, false)
+	qm.connMu.Unlock()
+	return ch.Qos(10, 0, false)
 }
 
-// DeclareQueue is used to declare a queue for which messages will be sent to
+// DeclareQueue is used to declare a queue for which messages will be sent to. The queue is
+// declared with a dead-letter exchange so a Nack'd message is automatically routed through
+// the companion retry queue (see declareDeadLetter) instead of being dropped.
 func (qm *Manager) DeclareQueue() error {
+	if err := qm.declareDeadLetter(); err != nil {
+		return err
+	}
 	// we declare the queue as durable so that even if rabbitmq server stops
 	// our messages won't be lost
-	q, err := qm.channel.QueueDeclare(
+	q, err := qm.channelRef().QueueDeclare(
 		qm.QueueName, // name
 		true,         // durable
 		false,        // delete when unused
 		false,        // exclusive
 		false,        // no-wait
-		nil,          // arguments
+		amqp.Table{
+			"x-dead-letter-exchange":    qm.QueueName + dlxSuffix,
+			"x-dead-letter-routing-key": qm.QueueName,
+		},
 	)
 	if err != nil {
 		return err
@@ -128,7 +150,12 @@ func (qm *Manager) DeclareQueue() error {
 	return nil
 }
 
-// ConsumeMessages is used to consume messages that are sent to the queue
+// ConsumeMessages is used to consume messages that are sent to the queue. It blocks for
+// the entire lifetime of ctx: superviseConnection performs the first bindAndConsume itself
+// and transparently redials/resumes consumption every time the broker drops us, so a
+// disconnect-and-reconnect never unwinds back to our caller as a returned error the way it
+// would if we raced a one-shot bindAndConsume here against a background supervisor. A
+// caller that wants ConsumeMessages to return should cancel ctx.
 // Question, do we really want to ack messages that fail to be processed?
 // Perhaps the error was temporary, and we allow it to be retried?
 func (qm *Manager) ConsumeMessages(ctx context.Context, wg *sync.WaitGroup, consumer, dbPass, dbURL, dbUser string, cfg *config.TemporalConfig) error {
@@ -146,6 +173,16 @@ func (qm *Manager) ConsumeMessages(ctx context.Context, wg *sync.WaitGroup, cons
 	qm.db = db
 	// embed config into queue manager
 	qm.cfg = cfg
+	// embed consumer tag so a reconnect can re-open the same consume stream
+	qm.consumer = consumer
+
+	return qm.superviseConnection(ctx, wg, func() error { return qm.bindAndConsume(ctx, wg) })
+}
+
+// bindAndConsume binds qm.QueueName to its exchange (if any), opens a Consume stream, and
+// dispatches deliveries to the queue-specific processor. superviseConnection re-invokes this
+// after a reconnect so consumption picks back up without the original caller noticing.
+func (qm *Manager) bindAndConsume(ctx context.Context, wg *sync.WaitGroup) error {
 	// if we are using an exchange, we form a relationship between a queue and an exchange
 	// this process is known as binding, and allows consumers to receive messages sent to an exchange
 	// We are primarily doing this to allow for multiple consumers, to receive the same message
@@ -154,7 +191,7 @@ func (qm *Manager) ConsumeMessages(ctx context.Context, wg *sync.WaitGroup, cons
 	// will have the same key in their keystore
 	switch qm.ExchangeName {
 	case PinRemovalExchange, PinExchange, IpfsKeyExchange:
-		if err = qm.channel.QueueBind(
+		if err := qm.channelRef().QueueBind(
 			qm.QueueName,    // name of the queue
 			"",              // routing key
 			qm.ExchangeName, // exchange
@@ -168,9 +205,9 @@ func (qm *Manager) ConsumeMessages(ctx context.Context, wg *sync.WaitGroup, cons
 	}
 
 	// we do not auto-ack, as if a consumer dies we don't want the message to be lost
-	msgs, err := qm.channel.Consume(
+	msgs, err := qm.channelRef().Consume(
 		qm.QueueName, // queue
-		consumer,     // consumer
+		qm.consumer,  // consumer
 		false,        // auto-ack
 		false,        // exclusive
 		false,        // no-local
@@ -203,7 +240,9 @@ func (qm *Manager) ConsumeMessages(ctx context.Context, wg *sync.WaitGroup, cons
 	}
 }
 
-//PublishMessageWithExchange is used to publish a message to a given exchange
+//PublishMessageWithExchange is used to publish a JSON-encoded message to a given exchange.
+// See PublishProtoMessageWithExchange for the protobuf-encoded, schema-versioned
+// equivalent newer producers should prefer.
 func (qm *Manager) PublishMessageWithExchange(body interface{}, exchangeName string) error {
 	switch exchangeName {
 	case PinExchange, PinRemovalExchange, IpfsKeyExchange:
@@ -215,47 +254,50 @@ func (qm *Manager) PublishMessageWithExchange(body interface{}, exchangeName str
 	if err != nil {
 		return err
 	}
-	if err = qm.channel.Publish(
-		exchangeName, // exchange - this determines which exchange will receive the message
-		"",           // routing key
-		false,        // mandatory
-		false,        // immediate
-		amqp.Publishing{
-			DeliveryMode: amqp.Persistent, // messages will persist through crashes, etc..
-			ContentType:  "text/plain",
-			Body:         bodyMarshaled,
-		},
-	); err != nil {
-		return err
-	}
-	return nil
+	return qm.publishWithConfirm(exchangeName, "", "text/plain", nil, bodyMarshaled)
 }
 
-// PublishMessage is used to produce messages that are sent to the queue, with a worker queue (one consumer)
+// PublishMessage is used to produce JSON-encoded messages that are sent to the queue, with
+// a worker queue (one consumer). See PublishProtoMessage for the protobuf-encoded,
+// schema-versioned equivalent newer producers should prefer.
 func (qm *Manager) PublishMessage(body interface{}) error {
 	bodyMarshaled, err := json.Marshal(body)
 	if err != nil {
 		return err
 	}
-	if err = qm.channel.Publish(
-		"",            // exchange - this is left empty, and becomes the default exchange
-		qm.queue.Name, // routing key
-		false,         // mandatory
-		false,         // immediate
-		amqp.Publishing{
-			DeliveryMode: amqp.Persistent, // messages will persist through crashes, etc..
-			ContentType:  "text/plain",
-			Body:         bodyMarshaled,
-		},
-	); err != nil {
+	return qm.publishWithConfirm("", qm.queue.Name, "text/plain", nil, bodyMarshaled)
+}
+
+// PublishProtoMessageWithExchange is used to publish a protobuf-encoded, schema-versioned
+// message to a given exchange.
+func (qm *Manager) PublishProtoMessageWithExchange(body SchemaMessage, exchangeName string) error {
+	switch exchangeName {
+	case PinExchange, PinRemovalExchange, IpfsKeyExchange:
+		break
+	default:
+		return errors.New("invalid exchange name provided")
+	}
+	bodyMarshaled, err := proto.Marshal(body)
+	if err != nil {
 		return err
 	}
-	return nil
+	return qm.publishWithConfirm(exchangeName, "", protoContentType, envelopeHeaders(body), bodyMarshaled)
+}
+
+// PublishProtoMessage is used to produce protobuf-encoded, schema-versioned messages that
+// are sent to the queue, with a worker queue (one consumer)
+func (qm *Manager) PublishProtoMessage(body SchemaMessage) error {
+	bodyMarshaled, err := proto.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return qm.publishWithConfirm("", qm.queue.Name, protoContentType, envelopeHeaders(body), bodyMarshaled)
 }
 
 // Close is used to close our queue resources
 func (qm *Manager) Close() {
-	if err := qm.channel.Close(); err != nil {
+	qm.closed = true
+	if err := qm.channelRef().Close(); err != nil {
 		qm.LogError(err, "failed to properly close channel")
 	} else {
 		qm.LogInfo("properly shutdown channel")
@@ -0,0 +1,291 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// reconnectDelayMin/Max bound the exponential backoff applied between redial attempts, so
+// a prolonged broker outage turns into patient retries rather than a dial tight-loop
+const (
+	reconnectDelayMin = 1 * time.Second
+	reconnectDelayMax = 30 * time.Second
+)
+
+// maxBufferedUnacked bounds how many unconfirmed publishes bufferUnacked will hold at
+// once, so a prolonged outage can't grow the buffer without bound; once full, the oldest
+// entry is dropped (and logged) in favor of the newest ones.
+const maxBufferedUnacked = 1000
+
+// pendingPublish is a message that was handed to the broker but never confirmed, either
+// because it was nacked or because the channel died before a confirm arrived. It is kept
+// around, keyed by tag, so flushUnacked can retry it once the connection is healthy again.
+type pendingPublish struct {
+	tag         uint64
+	exchange    string
+	routingKey  string
+	contentType string
+	headers     amqp.Table
+	body        []byte
+}
+
+// session bundles the channel and notification channels that change together every time
+// qm reconnects. Publishers read it via loadSession under connMu so they never observe a
+// channel from one dial alongside notify channels from another.
+type session struct {
+	channel         *amqp.Channel
+	notifyChanClose chan *amqp.Error
+	notifyConfirm   chan amqp.Confirmation
+}
+
+// loadSession returns the current session under a read lock
+func (qm *Manager) loadSession() *session {
+	qm.connMu.RLock()
+	defer qm.connMu.RUnlock()
+	return qm.sess
+}
+
+// channelRef returns the channel from the current session under a read lock, for callers
+// (DeclareQueue, NackForRetry, bindAndConsume, ...) that only need the channel and don't
+// care about the notify channels
+func (qm *Manager) channelRef() *amqp.Channel {
+	return qm.loadSession().channel
+}
+
+// enableConfirms puts qm's channel into publisher-confirm mode, wires up the close/confirm
+// notification channels, and publishes the result as qm's current session under a write
+// lock, so concurrent publishers never see a channel and notify-chans from different dials.
+func (qm *Manager) enableConfirms() error {
+	qm.connMu.RLock()
+	ch := qm.channel
+	conn := qm.connection
+	qm.connMu.RUnlock()
+
+	if err := ch.Confirm(false); err != nil {
+		return err
+	}
+	notifyConnClose := make(chan *amqp.Error, 1)
+	notifyChanClose := make(chan *amqp.Error, 1)
+	notifyConfirm := make(chan amqp.Confirmation, 1)
+	conn.NotifyClose(notifyConnClose)
+	ch.NotifyClose(notifyChanClose)
+	ch.NotifyPublish(notifyConfirm)
+
+	qm.connMu.Lock()
+	qm.notifyConnClose = notifyConnClose
+	qm.sess = &session{channel: ch, notifyChanClose: notifyChanClose, notifyConfirm: notifyConfirm}
+	qm.connMu.Unlock()
+	return nil
+}
+
+// publishWithConfirm publishes body to exchange (or, when exchange is empty, directly to
+// routingKey on the default exchange) and blocks until the broker acks or nacks it, so a
+// dead channel or a nack surfaces as an error instead of silently dropping the message.
+// Publishes are serialized so a confirm always corresponds to the publish that just ran;
+// the channel and notify channels used are snapshotted from a single session so a
+// concurrent reconnect can't swap them out from under an in-flight publish.
+func (qm *Manager) publishWithConfirm(exchange, routingKey, contentType string, headers amqp.Table, body []byte) error {
+	qm.publishMu.Lock()
+	defer qm.publishMu.Unlock()
+
+	sess := qm.loadSession()
+	pending := pendingPublish{exchange: exchange, routingKey: routingKey, contentType: contentType, headers: headers, body: body}
+	if err := sess.channel.Publish(
+		exchange,
+		routingKey,
+		false, // mandatory
+		false, // immediate
+		amqp.Publishing{
+			DeliveryMode: amqp.Persistent, // messages will persist through crashes, etc..
+			ContentType:  contentType,
+			Headers:      headers,
+			Body:         body,
+		},
+	); err != nil {
+		qm.bufferUnacked(pending)
+		return err
+	}
+
+	select {
+	case confirm, ok := <-sess.notifyConfirm:
+		if !ok || !confirm.Ack {
+			qm.bufferUnacked(pending)
+			return errors.New("message was nacked by the broker, buffered for retry")
+		}
+		return nil
+	case amqpErr, ok := <-sess.notifyChanClose:
+		qm.bufferUnacked(pending)
+		if ok {
+			return fmt.Errorf("channel closed while awaiting publish confirm: %v", amqpErr)
+		}
+		return errors.New("channel closed while awaiting publish confirm")
+	}
+}
+
+// bufferUnacked stashes a message that didn't make it through cleanly so flushUnacked can
+// retry it once the connection has been restored. The buffer is capped at
+// maxBufferedUnacked; once full, the oldest buffered message is dropped to make room.
+func (qm *Manager) bufferUnacked(p pendingPublish) {
+	qm.unackedMu.Lock()
+	defer qm.unackedMu.Unlock()
+
+	qm.unackedSeq++
+	p.tag = qm.unackedSeq
+	if qm.unacked == nil {
+		qm.unacked = make(map[uint64]pendingPublish, maxBufferedUnacked)
+	}
+	if len(qm.unacked) >= maxBufferedUnacked {
+		var oldest uint64
+		first := true
+		for tag := range qm.unacked {
+			if first || tag < oldest {
+				oldest, first = tag, false
+			}
+		}
+		delete(qm.unacked, oldest)
+		qm.LogError(errors.New("unacked publish buffer full"), "dropped oldest buffered publish")
+	}
+	qm.unacked[p.tag] = p
+}
+
+// flushUnacked republishes every message buffered by bufferUnacked. It is called once per
+// successful redial, after the channel and topology have been restored.
+func (qm *Manager) flushUnacked() {
+	qm.unackedMu.Lock()
+	pending := qm.unacked
+	qm.unacked = nil
+	qm.unackedMu.Unlock()
+
+	for _, p := range pending {
+		if err := qm.publishWithConfirm(p.exchange, p.routingKey, p.contentType, p.headers, p.body); err != nil {
+			qm.LogError(err, "failed to republish buffered message after reconnect")
+		}
+	}
+}
+
+// superviseConnection is the one true resume loop for a Manager: it runs resume itself
+// before ever watching for a disconnect, then watches the connection and channel
+// NotifyClose signals for the lifetime of ctx, transparently redialing with exponential
+// backoff and re-invoking resume every time either one fires. A caller like
+// ConsumeMessages blocks directly on superviseConnection rather than calling resume (e.g.
+// bindAndConsume) itself first, so there is never a window where a disconnect has to
+// unwind back out as a returned error - the redial/resume is indistinguishable from the
+// initial attempt. resume may be nil, in which case superviseConnection only redials
+// (publish-only managers rely on flushUnacked to replay buffered publishes instead).
+// Returns nil once ctx is done, qm is closed, or redial gives up because ctx was
+// cancelled while waiting to reconnect.
+func (qm *Manager) superviseConnection(ctx context.Context, wg *sync.WaitGroup, resume func() error) error {
+	if wg != nil {
+		wg.Add(1)
+		defer wg.Done()
+	}
+
+	if resume != nil {
+		if err := resume(); err != nil {
+			qm.LogError(err, "consumption ended, waiting for reconnect")
+		}
+	}
+
+	for {
+		qm.connMu.RLock()
+		notifyConnClose := qm.notifyConnClose
+		qm.connMu.RUnlock()
+		notifyChanClose := qm.loadSession().notifyChanClose
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-notifyConnClose:
+			if qm.closed {
+				return nil
+			}
+			qm.LogError(errOrClosed(err, ok), "amqp connection closed, reconnecting")
+		case err, ok := <-notifyChanClose:
+			if qm.closed {
+				return nil
+			}
+			qm.LogError(errOrClosed(err, ok), "amqp channel closed, reconnecting")
+		}
+
+		if err := qm.redial(ctx); err != nil {
+			// ctx was cancelled while we were waiting to redial
+			return nil
+		}
+		if resume != nil {
+			if err := resume(); err != nil {
+				qm.LogError(err, "failed to resume after reconnect")
+			}
+		}
+	}
+}
+
+func errOrClosed(err *amqp.Error, ok bool) error {
+	if !ok || err == nil {
+		return errors.New("connection closed")
+	}
+	return err
+}
+
+// redial re-dials the broker with exponential backoff until it succeeds or ctx is
+// cancelled, then re-opens a confirm-mode channel, replays the exchange/queue declarations
+// made in Initialize, and flushes any publishes that were left unconfirmed.
+func (qm *Manager) redial(ctx context.Context) error {
+	delay := reconnectDelayMin
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		conn, err := setupConnection(qm.connectionURL, qm.tlsConfig)
+		if err == nil {
+			qm.connMu.Lock()
+			qm.connection = conn
+			qm.connMu.Unlock()
+			if err = qm.OpenChannel(); err == nil {
+				if err = qm.enableConfirms(); err == nil {
+					if err = qm.redeclareTopology(); err == nil {
+						qm.flushUnacked()
+						return nil
+					}
+				}
+			}
+		}
+		qm.LogError(err, "reconnect attempt failed, backing off")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > reconnectDelayMax {
+			delay = reconnectDelayMax
+		}
+	}
+}
+
+// redeclareTopology replays the exchange/queue/dead-letter declarations that Initialize
+// originally made for qm.logicalQueue, since RabbitMQ does not remember them across a
+// dropped connection.
+func (qm *Manager) redeclareTopology() error {
+	switch qm.logicalQueue {
+	case IpfsPinQueue:
+		if err := qm.DeclareIPFSPinExchange(); err != nil {
+			return err
+		}
+	case IpfsKeyCreationQueue:
+		if err := qm.DeclareIPFSKeyExchange(); err != nil {
+			return err
+		}
+	}
+	if qm.publish {
+		return nil
+	}
+	return qm.DeclareQueue()
+}
@@ -0,0 +1,151 @@
+package queue
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	rabbithole "github.com/michaelklishin/rabbit-hole"
+)
+
+// statsCacheTTL bounds how often Stats actually calls out to the RabbitMQ management API,
+// so an admin polling the stats endpoint doesn't hammer the broker
+const statsCacheTTL = 15 * time.Second
+
+// ManagementConfig holds the credentials Stats needs to talk to the RabbitMQ HTTP
+// management API. It is independent of the consumer-only config embedded by
+// ConsumeMessages, since a publish-only Manager (such as the one the admin API uses to
+// call Stats) never calls ConsumeMessages.
+type ManagementConfig struct {
+	ManagementURL      string
+	ManagementUsername string
+	ManagementPassword string
+}
+
+// QueueStats summarizes a single queue's backlog and throughput as reported by the
+// RabbitMQ management API
+type QueueStats struct {
+	Name            string  `json:"name"`
+	MessagesReady   int     `json:"messages_ready"`
+	MessagesUnacked int     `json:"messages_unacknowledged"`
+	Consumers       int     `json:"consumers"`
+	PublishRate     float64 `json:"publish_rate"`
+	DeliverRate     float64 `json:"deliver_rate"`
+	AckRate         float64 `json:"ack_rate"`
+}
+
+// ExchangeStats summarizes message flow through a single exchange
+type ExchangeStats struct {
+	Name           string  `json:"name"`
+	PublishInRate  float64 `json:"publish_in_rate"`
+	PublishOutRate float64 `json:"publish_out_rate"`
+}
+
+// BrokerStats is the aggregated payload returned by Stats
+type BrokerStats struct {
+	Queues    []QueueStats    `json:"queues"`
+	Exchanges []ExchangeStats `json:"exchanges"`
+	FetchedAt time.Time       `json:"fetched_at"`
+}
+
+// temporalQueues lists every work queue Stats reports on
+var temporalQueues = []string{
+	IpfsPinQueue, IpfsFileQueue, IpfsKeyCreationQueue, IpnsEntryQueue,
+	EmailSendQueue, DatabaseFileAddQueue, IpfsClusterPinQueue,
+}
+
+// temporalExchanges lists every exchange Stats reports on
+var temporalExchanges = []string{PinExchange, PinRemovalExchange, IpfsKeyExchange}
+
+var (
+	statsCacheMu   sync.Mutex
+	statsCache     *BrokerStats
+	statsCacheTime time.Time
+)
+
+// isTemporalQueue reports whether name is one of temporalQueues, accounting for the
+// "<hostname>+<queueName>" form parseQueueName gives IpfsPinQueue/IpfsKeyCreationQueue
+func isTemporalQueue(name string) bool {
+	for _, q := range temporalQueues {
+		if name == q || strings.HasSuffix(name, "+"+q) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTemporalExchange reports whether name is one of temporalExchanges
+func isTemporalExchange(name string) bool {
+	for _, e := range temporalExchanges {
+		if name == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Stats queries the RabbitMQ HTTP management API for per-queue and per-exchange message
+// stats. Results are cached for statsCacheTTL, since the admin stats endpoint this backs
+// can be polled far more often than the management API should be hit. qm.management must
+// be set (via Initialize) regardless of whether qm is a publisher or a consumer, since
+// Stats is typically called against the admin API's publish-only Manager.
+func (qm *Manager) Stats() (*BrokerStats, error) {
+	statsCacheMu.Lock()
+	defer statsCacheMu.Unlock()
+	if statsCache != nil && time.Since(statsCacheTime) < statsCacheTTL {
+		return statsCache, nil
+	}
+
+	if qm.management == nil {
+		return nil, errors.New("queue manager has no management api credentials configured")
+	}
+	client, err := rabbithole.NewClient(
+		qm.management.ManagementURL,
+		qm.management.ManagementUsername,
+		qm.management.ManagementPassword,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	const vhost = "/"
+	queues, err := client.ListQueuesIn(vhost)
+	if err != nil {
+		return nil, err
+	}
+	exchanges, err := client.ListExchangesIn(vhost)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &BrokerStats{FetchedAt: time.Now()}
+	for _, q := range queues {
+		if !isTemporalQueue(q.Name) {
+			continue
+		}
+		stats.Queues = append(stats.Queues, QueueStats{
+			Name:            q.Name,
+			MessagesReady:   q.MessagesReady,
+			MessagesUnacked: q.MessagesUnacknowledged,
+			Consumers:       q.Consumers,
+			PublishRate:     float64(q.MessageStats.PublishDetails.Rate),
+			DeliverRate:     float64(q.MessageStats.DeliverDetails.Rate),
+			AckRate:         float64(q.MessageStats.AckDetails.Rate),
+		})
+	}
+	for _, e := range exchanges {
+		if !isTemporalExchange(e.Name) {
+			continue
+		}
+		stats.Exchanges = append(stats.Exchanges, ExchangeStats{
+			Name:           e.Name,
+			PublishInRate:  float64(e.MessageStats.PublishInDetails.Rate),
+			PublishOutRate: float64(e.MessageStats.PublishOutDetails.Rate),
+		})
+	}
+
+	statsCache = stats
+	statsCacheTime = time.Now()
+	return stats, nil
+}
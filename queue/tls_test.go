@@ -0,0 +1,115 @@
+package queue
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSelfSignedCA generates a self-signed CA certificate, writes it (PEM-encoded) to a
+// file under dir, and returns that file's path alongside the CA's parsed certificate/key
+// so callers can mint leaf certs signed by it.
+func writeSelfSignedCA(t *testing.T, dir string) (string, *x509.Certificate, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate CA key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "temporal-test-ca"},
+		NotBefore:             time.Unix(0, 0),
+		NotAfter:              time.Unix(0, 0).Add(24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create CA certificate: %s", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse CA certificate: %s", err)
+	}
+
+	path := filepath.Join(dir, "ca.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := ioutil.WriteFile(path, pemBytes, 0600); err != nil {
+		t.Fatalf("failed to write CA file: %s", err)
+	}
+	return path, cert, key
+}
+
+func TestBuildTLSConfigLoadsCA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue-tls-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	caPath, caCert, _ := writeSelfSignedCA(t, dir)
+
+	tlsConfig, err := buildTLSConfig(&QueueTLSConfig{CAFile: caPath, ServerName: "rabbitmq.example.com"})
+	if err != nil {
+		t.Fatalf("buildTLSConfig returned an error: %s", err)
+	}
+	if tlsConfig.ServerName != "rabbitmq.example.com" {
+		t.Errorf("expected ServerName to be carried through, got %q", tlsConfig.ServerName)
+	}
+	if tlsConfig.RootCAs == nil {
+		t.Fatal("expected RootCAs to be populated from CAFile")
+	}
+	want := x509.NewCertPool()
+	want.AddCert(caCert)
+	if !tlsConfig.RootCAs.Equal(want) {
+		t.Error("expected RootCAs to contain exactly the loaded CA certificate")
+	}
+}
+
+func TestBuildTLSConfigMissingCAFile(t *testing.T) {
+	_, err := buildTLSConfig(&QueueTLSConfig{CAFile: "/nonexistent/ca.pem"})
+	if err == nil {
+		t.Fatal("expected an error for a missing CA file, got nil")
+	}
+}
+
+func TestBuildTLSConfigInvalidCAFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue-tls-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	badPath := filepath.Join(dir, "bad.pem")
+	if err := ioutil.WriteFile(badPath, []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("failed to write bad CA file: %s", err)
+	}
+
+	_, err = buildTLSConfig(&QueueTLSConfig{CAFile: badPath})
+	if err == nil {
+		t.Fatal("expected an error for an invalid CA file, got nil")
+	}
+}
+
+func TestBuildTLSConfigNoCAUsesSystemPool(t *testing.T) {
+	tlsConfig, err := buildTLSConfig(&QueueTLSConfig{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("buildTLSConfig returned an error: %s", err)
+	}
+	if tlsConfig.RootCAs != nil {
+		t.Error("expected RootCAs to stay nil (defer to the system pool) when no CAFile is set")
+	}
+	if !tlsConfig.InsecureSkipVerify {
+		t.Error("expected InsecureSkipVerify to be carried through")
+	}
+}